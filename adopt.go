@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var aliasLine = regexp.MustCompile(`^(\s*)alias\s+([A-Za-z0-9_.-]+)=(.+)$`)
+
+// adoptedConfig is the config.yaml written for an adopted alias.
+type adoptedConfig struct {
+	Name   string           `yaml:"name"`
+	Args   []string         `yaml:"args,omitempty"`
+	Agents map[string]Agent `yaml:"agents,omitempty"`
+}
+
+// adopt converts `alias cc='claude --system-prompt ...'` style aliases in
+// shell rc files into personas, optionally pointing the aliases at unum:
+// unum adopt [--rc file]... [--rewrite] [--dry-run]
+func adopt(argv []string) error {
+	var rcFiles []string
+	rewrite, dryRun := false, false
+	for i := 0; i < len(argv); i++ {
+		switch argv[i] {
+		case "--rc":
+			if i+1 >= len(argv) {
+				return fmt.Errorf("--rc requires a file")
+			}
+			i++
+			rcFiles = append(rcFiles, argv[i])
+		case "--rewrite":
+			rewrite = true
+		case "--dry-run":
+			dryRun = true
+		default:
+			return fmt.Errorf("usage: unum adopt [--rc file]... [--rewrite] [--dry-run]")
+		}
+	}
+	if len(rcFiles) == 0 {
+		home, _ := os.UserHomeDir()
+		for _, name := range []string{".bashrc", ".bash_aliases", ".bash_profile", ".zshrc", ".zprofile"} {
+			if _, err := os.Stat(filepath.Join(home, name)); err == nil {
+				rcFiles = append(rcFiles, filepath.Join(home, name))
+			}
+		}
+	}
+
+	adopted := 0
+	for _, rc := range rcFiles {
+		n, err := adoptFile(rc, rewrite, dryRun)
+		if err != nil {
+			return err
+		}
+		adopted += n
+	}
+	if adopted == 0 {
+		fmt.Println("No claude aliases found")
+	}
+	return nil
+}
+
+func adoptFile(rc string, rewrite, dryRun bool) (int, error) {
+	data, err := os.ReadFile(rc)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	adopted, changed := 0, false
+	for i, line := range lines {
+		// Keep CRLF line endings intact when rewriting
+		line, crlf := strings.CutSuffix(line, "\r")
+		m := aliasLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		indent, name := m[1], m[2]
+		words, err := shellSplit(m[3])
+		if err != nil || len(words) != 1 {
+			continue
+		}
+		command := words[0]
+		args, err := shellSplit(command)
+		if err != nil || len(args) == 0 || filepath.Base(args[0]) != "claude" {
+			continue
+		}
+		if strings.ContainsAny(command, "$`") {
+			fmt.Fprintf(os.Stderr, "Skipping alias %s in %s: uses shell expansion, convert it by hand\n", name, rc)
+			continue
+		}
+		if err := adoptable(name, args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping alias %s in %s: %v\n", name, rc, err)
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("Would create persona %s from %s:%d\n", name, rc, i+1)
+		} else if err := writeAdopted(name, args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping alias %s in %s: %v\n", name, rc, err)
+			continue
+		}
+		adopted++
+
+		if rewrite {
+			lines[i] = fmt.Sprintf("%salias %s='unum %s'", indent, name, name)
+			if crlf {
+				lines[i] += "\r"
+			}
+			changed = true
+		}
+	}
+
+	if !changed {
+		return adopted, nil
+	}
+	if dryRun {
+		fmt.Printf("Would rewrite %d alias(es) in %s to call unum\n", adopted, rc)
+		return adopted, nil
+	}
+
+	// Write through symlinks, e.g. rc files kept in a dotfiles repo
+	target, err := filepath.EvalSymlinks(rc)
+	if err != nil {
+		return adopted, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return adopted, err
+	}
+	backup, err := writeBackup(rc, data, info.Mode().Perm())
+	if err != nil {
+		return adopted, err
+	}
+	if err := replaceFile(target, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return adopted, err
+	}
+	fmt.Printf("Rewrote aliases in %s (backup: %s)\n", rc, backup)
+	return adopted, nil
+}
+
+// writeBackup saves data as rc.unum-backup, or a timestamped name when that
+// exists, so the backup of the original rc file is never overwritten.
+func writeBackup(rc string, data []byte, perm os.FileMode) (string, error) {
+	backup := rc + ".unum-backup"
+	if _, err := os.Lstat(backup); err == nil {
+		backup = rc + ".unum-backup-" + time.Now().Format("20060102-150405")
+	}
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return backup, f.Close()
+}
+
+// replaceFile writes data to a temp file next to path and renames it into
+// place, so a failed write can't leave a truncated file behind.
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".unum-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// adoptable rejects aliases that wouldn't work as personas: names unum uses
+// itself, and aliases without a system prompt, whose persona would replace
+// claude's default prompt with an empty one.
+func adoptable(name string, args []string) error {
+	if commands[name] || reservedNames[name] {
+		return fmt.Errorf("%q is a unum command, rename the alias first", name)
+	}
+	for _, arg := range args {
+		flag, _, _ := strings.Cut(arg, "=")
+		if flag == "--system-prompt" || flag == "--system-prompt-file" {
+			return nil
+		}
+	}
+	return fmt.Errorf("no --system-prompt, so the persona would replace claude's default prompt with an empty one")
+}
+
+// writeAdopted creates a directory-layout persona from claude's arguments.
+func writeAdopted(name string, args []string) error {
+	dir := personaDir(name)
+	for _, path := range []string{dir, configPath(name)} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("config already exists: %s", path)
+		}
+	}
+
+	cfg := adoptedConfig{Name: name}
+	prompt := ""
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		takesValue := flag == "--system-prompt" || flag == "--system-prompt-file" || flag == "--agents"
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch flag {
+		case "--system-prompt":
+			prompt = value
+		case "--system-prompt-file":
+			data, err := os.ReadFile(expandHome(value))
+			if err != nil {
+				return err
+			}
+			prompt = string(data)
+		case "--agents":
+			if err := json.Unmarshal([]byte(value), &cfg.Agents); err != nil {
+				return fmt.Errorf("invalid --agents: %w", err)
+			}
+		default:
+			cfg.Args = append(cfg.Args, args[i])
+		}
+	}
+
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(out.String()), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prompt.md"), []byte(prompt), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s\n", dir)
+	return nil
+}
+
+// shellSplit splits s into words using POSIX shell quoting rules (no
+// expansion).
+func shellSplit(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			cur.WriteByte(s[i])
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			i = len(s) // comment
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestShellSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{name: "plain words", in: "claude --model opus", want: []string{"claude", "--model", "opus"}},
+		{name: "extra whitespace", in: "  claude \t-p  ", want: []string{"claude", "-p"}},
+		{name: "single quotes", in: `claude --system-prompt 'be "terse"'`, want: []string{"claude", "--system-prompt", `be "terse"`}},
+		{name: "double quotes", in: `claude --system-prompt "it's fine"`, want: []string{"claude", "--system-prompt", "it's fine"}},
+		{name: "escapes in double quotes", in: `"a \"b\" \$c \\ \d"`, want: []string{`a "b" $c \ \d`}},
+		{name: "backslash escapes", in: `a\ b c\'d`, want: []string{"a b", "c'd"}},
+		{name: "adjacent quotes join", in: `'a'"b"c`, want: []string{"abc"}},
+		{name: "empty quotes", in: `claude ''`, want: []string{"claude", ""}},
+		{name: "comment", in: "claude --model opus # fast", want: []string{"claude", "--model", "opus"}},
+		{name: "hash inside word", in: "a#b", want: []string{"a#b"}},
+		{name: "hash in quotes", in: "'# not a comment'", want: []string{"# not a comment"}},
+		{name: "CRLF line", in: "'claude -p'\r", want: []string{"claude -p"}},
+		{name: "unterminated single quote", in: "'claude", wantErr: true},
+		{name: "unterminated double quote", in: `"claude`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shellSplit(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("shellSplit(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("shellSplit(%q): %v", tt.in, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("shellSplit(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdoptRewrite(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		rc       string
+		want     string
+		personas map[string]string // persona -> prompt.md
+	}{
+		{
+			name: "rewrites claude aliases",
+			rc: "export EDITOR=vim\n" +
+				"alias rv='claude --system-prompt \"Review carefully\" --model opus'\n" +
+				"  alias ll='ls -l'\n",
+			want: "export EDITOR=vim\n" +
+				"alias rv='unum rv'\n" +
+				"  alias ll='ls -l'\n",
+			personas: map[string]string{"rv": "Review carefully"},
+		},
+		{
+			name:     "keeps indentation and CRLF",
+			rc:       "  alias wr=\"claude --system-prompt 'Write docs'\"\r\nalias x=y\r\n",
+			want:     "  alias wr='unum wr'\r\nalias x=y\r\n",
+			personas: map[string]string{"wr": "Write docs"},
+		},
+		{
+			name: "skips what it can't adopt",
+			rc: "alias co='claude --model opus'\n" +
+				"alias here='claude --system-prompt x'\n" +
+				"alias ex='claude --system-prompt \"$PROMPT\"'\n",
+			want: "alias co='claude --model opus'\n" +
+				"alias here='claude --system-prompt x'\n" +
+				"alias ex='claude --system-prompt \"$PROMPT\"'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := filepath.Join(t.TempDir(), ".bashrc")
+			if err := os.WriteFile(rc, []byte(tt.rc), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := adoptFile(rc, true, false); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := os.ReadFile(rc)
+			if string(got) != tt.want {
+				t.Errorf("rc = %q, want %q", got, tt.want)
+			}
+			if info, err := os.Stat(rc); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("rc mode = %v (%v), want 0600", info.Mode().Perm(), err)
+			}
+			backup, err := os.ReadFile(rc + ".unum-backup")
+			if len(tt.personas) == 0 {
+				if err == nil {
+					t.Errorf("backup written although nothing was rewritten")
+				}
+			} else if string(backup) != tt.rc {
+				t.Errorf("backup = %q (%v), want the original rc", backup, err)
+			}
+			for name, prompt := range tt.personas {
+				got, err := os.ReadFile(filepath.Join(personaDir(name), "prompt.md"))
+				if err != nil || string(got) != prompt {
+					t.Errorf("%s prompt.md = %q (%v), want %q", name, got, err, prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestAdoptKeepsFirstBackup(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	rc := filepath.Join(dir, ".zshrc")
+	original := "alias a='claude --system-prompt A'\n"
+	if err := os.WriteFile(rc, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adoptFile(rc, true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second run with a new alias backs up to a new file
+	f, err := os.OpenFile(rc, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\nalias b='claude --system-prompt B'\n")
+	f.Close()
+	if _, err := adoptFile(rc, true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := os.ReadFile(rc + ".unum-backup"); string(got) != original {
+		t.Errorf("first backup = %q, want %q", got, original)
+	}
+	backups, _ := filepath.Glob(rc + ".unum-backup-*")
+	if len(backups) != 1 {
+		t.Fatalf("timestamped backups = %q, want one", backups)
+	}
+	if got, _ := os.ReadFile(backups[0]); !strings.Contains(string(got), "alias a='unum a'") {
+		t.Errorf("second backup = %q, want the rc as of the second run", got)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// ask runs a single-turn headless question with a cheap model. When the
+// project already has a session, it is forked so the question sees that
+// context; the fork isn't saved, so the next --continue still resumes the
+// original conversation.
+func ask(persona string, argv []string) error {
+	if len(argv) == 0 || argv[0] == "" {
+		return fmt.Errorf("usage: unum %s ask \"<question>\" [flags...]", persona)
+	}
+	question, extraArgs := argv[0], argv[1:]
+
+	defaults, err := loadDefaults()
+	if err != nil {
+		return err
+	}
+
+	l, err := prepare(persona, append(extraArgs, "--max-turns", "1", "--no-session-persistence", "-p", question))
+	if err != nil {
+		return err
+	}
+
+	if hasTranscripts(l) {
+		l.Args = append(l.Args, "--continue", "--fork-session")
+	}
+	// Appended last so it overrides any --model from the config
+	l.Args = append(l.Args, "--model", defaults.AskModel)
+	return l.exec()
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flagKind is the type of value a backend flag takes.
+type flagKind int
+
+const (
+	flagBool     flagKind = iota
+	flagString            // takes a value
+	flagInt               // takes an integer value
+	flagJSON              // takes inline JSON or a file path
+	flagOptional          // takes a value only if one follows
+	flagList              // takes one or more values
+)
+
+// flagSpec declares a backend flag. Managed flags are set by unum from the
+// persona config and must not appear in args:.
+type flagSpec struct {
+	Kind    flagKind
+	Managed string // what to use instead, e.g. "agents:"
+}
+
+// maxArgLen is the largest single exec argument Linux accepts
+// (MAX_ARG_STRLEN).
+const maxArgLen = 128 * 1024
+
+// promptFileMaxAge is how long superseded prompt files are kept.
+const promptFileMaxAge = 24 * time.Hour
+
+// backend describes the agent CLI unum launches and what it supports.
+type backend struct {
+	Name string
+	// PromptFileFlag passes the system prompt by path; "" if unsupported
+	PromptFileFlag string
+	// PrintFlags select headless mode, the only mode PromptFileFlag works in
+	PrintFlags []string
+	// Flags are the known command-line flags, used to validate args:
+	Flags map[string]flagSpec
+}
+
+var claudeBackend = backend{
+	Name:           "claude",
+	PromptFileFlag: "--system-prompt-file",
+	PrintFlags:     []string{"-p", "--print"},
+	Flags: map[string]flagSpec{
+		"--system-prompt":                {Kind: flagString, Managed: "prompt: or prompt.md"},
+		"--system-prompt-file":           {Kind: flagString, Managed: "prompt: or prompt.md"},
+		"--agents":                       {Kind: flagJSON, Managed: "agents: or agents/*.md"},
+		"--add-dir":                      {Kind: flagList},
+		"--model":                        {Kind: flagString},
+		"--fallback-model":               {Kind: flagString},
+		"--max-turns":                    {Kind: flagInt},
+		"--permission-mode":              {Kind: flagString},
+		"--permission-prompt-tool":       {Kind: flagString},
+		"--dangerously-skip-permissions": {Kind: flagBool},
+		"--allowedTools":                 {Kind: flagList},
+		"--allowed-tools":                {Kind: flagList},
+		"--disallowedTools":              {Kind: flagList},
+		"--disallowed-tools":             {Kind: flagList},
+		"--tools":                        {Kind: flagList},
+		"--mcp-config":                   {Kind: flagList},
+		"--strict-mcp-config":            {Kind: flagBool},
+		"--settings":                     {Kind: flagJSON},
+		"--setting-sources":              {Kind: flagString},
+		"--append-system-prompt":         {Kind: flagString},
+		"--plugin-dir":                   {Kind: flagList},
+		"--output-format":                {Kind: flagString},
+		"--input-format":                 {Kind: flagString},
+		"--include-partial-messages":     {Kind: flagBool},
+		"--verbose":                      {Kind: flagBool},
+		"--debug":                        {Kind: flagOptional},
+		"--ide":                          {Kind: flagBool},
+		"-p":                             {Kind: flagBool},
+		"--print":                        {Kind: flagBool},
+		"-c":                             {Kind: flagBool},
+		"--continue":                     {Kind: flagBool},
+		"-r":                             {Kind: flagOptional},
+		"--resume":                       {Kind: flagOptional},
+		"--fork-session":                 {Kind: flagBool},
+		"--no-session-persistence":       {Kind: flagBool},
+		"--session-id":                   {Kind: flagString},
+	},
+}
+
+// validateArgs checks args: entries against the backend's flags. Conflicts
+// are managed flags that clash with unum's own; errors would likely break
+// the launch; warnings flag flags the backend doesn't declare.
+func (b backend) validateArgs(args []string) (conflicts, errs, warnings []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			continue // positional, e.g. an initial prompt
+		}
+		flag, value, hasValue := strings.Cut(arg, "=")
+		spec, ok := b.Flags[flag]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is not a known %s flag", flag, b.Name))
+			continue
+		}
+		if spec.Managed != "" {
+			conflicts = append(conflicts, fmt.Sprintf("%s is managed by unum, use %s in the config instead", flag, spec.Managed))
+		}
+
+		switch spec.Kind {
+		case flagBool:
+			if hasValue {
+				errs = append(errs, fmt.Sprintf("%s does not take a value", flag))
+			}
+			continue
+		case flagOptional:
+			if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+			}
+			continue
+		}
+
+		// The next arg is the value even if it starts with a dash, e.g.
+		// --append-system-prompt "- be terse"
+		if !hasValue {
+			if i+1 >= len(args) {
+				errs = append(errs, fmt.Sprintf("%s expects a value", flag))
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		switch spec.Kind {
+		case flagInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s expects an integer, got %q", flag, value))
+			}
+		case flagJSON:
+			if strings.HasPrefix(strings.TrimSpace(value), "{") && !json.Valid([]byte(value)) {
+				errs = append(errs, fmt.Sprintf("%s expects valid JSON", flag))
+			}
+		case flagList:
+			for !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+			}
+		}
+	}
+	return conflicts, errs, warnings
+}
+
+// promptArgs passes the prompt inline, or by file once it is larger than
+// threshold bytes, to stay clear of exec argv limits. Prompt files only work
+// in print mode, so interactive launches (args without a print flag) stay
+// inline as long as the prompt fits in a single argument.
+func (b backend) promptArgs(prompt, sessDir string, threshold int, args []string) ([]string, error) {
+	if b.PromptFileFlag == "" || threshold <= 0 || len(prompt) <= threshold {
+		return []string{"--system-prompt", prompt}, nil
+	}
+	if !slices.ContainsFunc(args, func(arg string) bool { return slices.Contains(b.PrintFlags, arg) }) {
+		if len(prompt) < maxArgLen {
+			return []string{"--system-prompt", prompt}, nil
+		}
+		return nil, fmt.Errorf("prompt is %d bytes, too large to pass inline; %s only works with %s", len(prompt), b.PromptFileFlag, strings.Join(b.PrintFlags, "/"))
+	}
+	path, err := writePromptFile(sessDir, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return []string{b.PromptFileFlag, path}, nil
+}
+
+// writePromptFile stores the prompt in the session dir, readable only by
+// the current user. Files are named by content hash so relaunches reuse
+// them, and ones left behind by earlier versions of the prompt are removed
+// once they are promptFileMaxAge old.
+func writePromptFile(sessDir, prompt string) (string, error) {
+	sum := sha256.Sum256([]byte(prompt))
+	path := filepath.Join(sessDir, ".unum-prompt-"+hex.EncodeToString(sum[:6])+".md")
+
+	stale, _ := filepath.Glob(filepath.Join(sessDir, ".unum-prompt-*.md"))
+	for _, old := range stale {
+		if info, err := os.Stat(old); err == nil && old != path && time.Since(info.ModTime()) > promptFileMaxAge {
+			os.Remove(old)
+		}
+	}
+
+	// Write to a private temp file and rename, so an existing file or
+	// symlink at path is replaced rather than written through
+	tmp, err := os.CreateTemp(sessDir, ".unum-prompt-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.WriteString(prompt); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, nil
+}
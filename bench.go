@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// benchResult is the subset of claude's --output-format json result we report on.
+type benchResult struct {
+	IsError      bool    `json:"is_error"`
+	DurationMS   float64 `json:"duration_ms"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// bench runs a task headless several times and reports latency, token, and
+// cost statistics: unum bench <persona> -p "<task>" [-n 5] [flags...]
+func bench(argv []string) error {
+	if len(argv) < 1 {
+		return fmt.Errorf("usage: unum bench <persona> -p \"<task>\" [-n 5] [flags...]")
+	}
+	persona := argv[0]
+
+	task := ""
+	runs := 5
+	var extraArgs []string
+	for i := 1; i < len(argv); i++ {
+		switch argv[i] {
+		case "-p", "--print":
+			if i+1 >= len(argv) {
+				return fmt.Errorf("%s requires a task", argv[i])
+			}
+			i++
+			task = argv[i]
+		case "-n":
+			if i+1 >= len(argv) {
+				return fmt.Errorf("-n requires a count")
+			}
+			i++
+			n, err := strconv.Atoi(argv[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid run count: %s", argv[i])
+			}
+			runs = n
+		default:
+			extraArgs = append(extraArgs, argv[i])
+		}
+	}
+	if task == "" {
+		return fmt.Errorf("bench requires a task: -p \"<task>\"")
+	}
+
+	// Runs aren't saved, so they never show up in sessions or get resumed by
+	// --continue or "unum here"
+	l, err := prepare(persona, append(extraArgs, "-p", task, "--output-format", "json", "--no-session-persistence"))
+	if err != nil {
+		return err
+	}
+
+	var latencies, costs, inTokens, outTokens []float64
+	failures := 0
+	for i := 1; i <= runs; i++ {
+		cmd := exec.Command(l.Path, l.Args...)
+		cmd.Dir = l.Dir
+		cmd.Env = l.Env
+		cmd.Stderr = os.Stderr
+
+		start := time.Now()
+		out, err := cmd.Output()
+		elapsed := time.Since(start)
+
+		var res benchResult
+		if err == nil {
+			err = json.Unmarshal(bytes.TrimSpace(out), &res)
+		}
+		if err == nil && res.IsError {
+			err = fmt.Errorf("claude reported an error")
+		}
+		if err != nil {
+			failures++
+			fmt.Fprintf(os.Stderr, "run %d/%d: failed: %v\n", i, runs, err)
+			continue
+		}
+
+		in := res.Usage.InputTokens + res.Usage.CacheCreationInputTokens + res.Usage.CacheReadInputTokens
+		latencies = append(latencies, elapsed.Seconds())
+		costs = append(costs, res.TotalCostUSD)
+		inTokens = append(inTokens, float64(in))
+		outTokens = append(outTokens, float64(res.Usage.OutputTokens))
+		fmt.Printf("run %d/%d: %.1fs, %d in / %d out tokens, $%.4f\n",
+			i, runs, elapsed.Seconds(), in, res.Usage.OutputTokens, res.TotalCostUSD)
+	}
+
+	if len(latencies) == 0 {
+		return fmt.Errorf("all %d runs failed", runs)
+	}
+
+	fmt.Printf("\n%s: %d runs", persona, len(latencies))
+	if failures > 0 {
+		fmt.Printf(" (%d failed)", failures)
+	}
+	fmt.Println()
+	fmt.Printf("latency  p50 %.1fs  p90 %.1fs  min %.1fs  max %.1fs\n",
+		percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 0), percentile(latencies, 100))
+	fmt.Printf("tokens   in %.0f  out %.0f (mean)\n", mean(inTokens), mean(outTokens))
+	fmt.Printf("cost     mean $%.4f  stddev $%.4f  total $%.4f\n", mean(costs), stddev(costs), mean(costs)*float64(len(costs)))
+	return nil
+}
+
+// percentile uses nearest-rank on a sorted copy of values.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64) float64 {
+	m := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// claudeNotFoundError is returned when the claude binary can't be located.
+// Hints describe what was found on disk and how to repair the setup.
+type claudeNotFoundError struct {
+	Hints []string
+}
+
+func (e *claudeNotFoundError) Error() string {
+	var b strings.Builder
+	b.WriteString("claude not found in PATH")
+	for _, hint := range e.Hints {
+		b.WriteString("\n  - ")
+		b.WriteString(hint)
+	}
+	return b.String()
+}
+
+// findClaude locates the claude binary, honoring UNUM_CLAUDE_PATH.
+func findClaude() (string, error) {
+	if override := os.Getenv("UNUM_CLAUDE_PATH"); override != "" {
+		if !isExecutable(override) {
+			return "", fmt.Errorf("UNUM_CLAUDE_PATH=%s is not an executable file", override)
+		}
+		return override, nil
+	}
+
+	if path, err := exec.LookPath("claude"); err == nil {
+		return path, nil
+	}
+
+	return "", &claudeNotFoundError{Hints: diagnoseClaude()}
+}
+
+// diagnoseClaude looks for claude installs that exist but aren't reachable
+// through PATH, and falls back to platform install instructions.
+func diagnoseClaude() []string {
+	home, _ := os.UserHomeDir()
+	var hints []string
+
+	// npm global installs (custom prefix or default)
+	npmBins := []string{
+		filepath.Join(home, ".npm-global", "bin"),
+		filepath.Join(home, ".local", "bin"),
+		filepath.Join(home, ".claude", "local"),
+		"/usr/local/bin",
+		"/opt/homebrew/bin",
+	}
+	if npm, err := exec.LookPath("npm"); err == nil {
+		if out, err := exec.Command(npm, "prefix", "-g").Output(); err == nil {
+			prefix := strings.TrimSpace(string(out))
+			if runtime.GOOS == "windows" {
+				npmBins = append([]string{prefix}, npmBins...)
+			} else {
+				npmBins = append([]string{filepath.Join(prefix, "bin")}, npmBins...)
+			}
+		}
+	}
+	for _, dir := range npmBins {
+		if candidate := filepath.Join(dir, "claude"); isExecutable(candidate) {
+			hints = append(hints, fmt.Sprintf("found %s, but %s is not on PATH; add it to PATH or set UNUM_CLAUDE_PATH=%s", candidate, dir, candidate))
+			return hints
+		}
+	}
+
+	// nvm only sets up PATH in interactive shells
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		nvmDir = filepath.Join(home, ".nvm")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(nvmDir, "versions", "node", "*", "bin", "claude")); len(matches) > 0 {
+		candidate := matches[len(matches)-1]
+		hints = append(hints,
+			fmt.Sprintf("found %s under nvm; nvm only adds node to PATH in interactive shells", candidate),
+			fmt.Sprintf("set UNUM_CLAUDE_PATH=%s, or install claude outside nvm", candidate))
+		return hints
+	}
+
+	// snap binaries live in /snap/bin, which some sessions omit
+	if candidate := "/snap/bin/claude"; isExecutable(candidate) {
+		hints = append(hints, fmt.Sprintf("found snap alias %s, but /snap/bin is not on PATH; add it to PATH or set UNUM_CLAUDE_PATH=%s", candidate, candidate))
+		return hints
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		hints = append(hints, "install with: brew install --cask claude-code (or: npm install -g @anthropic-ai/claude-code)")
+	case "windows":
+		hints = append(hints, "install with: npm install -g @anthropic-ai/claude-code, then restart your terminal")
+	default:
+		hints = append(hints, "install with: curl -fsSL https://claude.ai/install.sh | bash (or: npm install -g @anthropic-ai/claude-code)")
+	}
+	hints = append(hints, "or point unum at an existing binary with UNUM_CLAUDE_PATH=/path/to/claude")
+	return hints
+}
+
+// claudeProjectDir is where claude, running with env, keeps transcripts for
+// sessions started in dir: every non-alphanumeric character of the path
+// becomes a dash.
+func claudeProjectDir(dir string, env []string) string {
+	base := getEnv(env, "CLAUDE_CONFIG_DIR")
+	if base == "" {
+		home, _ := os.UserHomeDir()
+		base = filepath.Join(home, ".claude")
+	}
+	encoded := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, dir)
+	return filepath.Join(base, "projects", encoded)
+}
+
+// hasTranscripts reports whether claude has any saved sessions for the launch.
+func hasTranscripts(l *launch) bool {
+	matches, _ := filepath.Glob(filepath.Join(claudeProjectDir(l.Dir, l.Env), "*.jsonl"))
+	return len(matches) > 0
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
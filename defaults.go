@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults holds user-level settings that apply to every persona.
+type Defaults struct {
+	GlobalPromptPrefix string       `yaml:"global_prompt_prefix"`
+	GlobalPromptSuffix string       `yaml:"global_prompt_suffix"`
+	AskModel           string       `yaml:"ask_model"`
+	Sources            []SourceSpec `yaml:"sources"`
+	// ReleaseNotesPersona drafts changelogs for "unum release-notes"
+	ReleaseNotesPersona string `yaml:"release_notes_persona"`
+	// PromptFileThreshold is the prompt size in bytes above which the prompt
+	// is passed by file; 0 disables
+	PromptFileThreshold int `yaml:"prompt_file_threshold"`
+	// SlowLaunchWarningMS warns when startup exceeds it; 0 disables
+	SlowLaunchWarningMS int `yaml:"slow_launch_warning_ms"`
+}
+
+func defaultsPath() string {
+	return filepath.Join(configDir(), "defaults.yaml")
+}
+
+// systemConfigDir holds organization-wide settings, e.g. from MDM.
+func systemConfigDir() string {
+	if dir := os.Getenv("UNUM_SYSTEM_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return "/etc/unum"
+}
+
+// defaultsFiles lists the files merged into the defaults, lowest priority
+// first: system conf.d fragments, defaults.yaml, then user conf.d fragments.
+// Fragments within a conf.d directory apply in lexical order.
+func defaultsFiles() []string {
+	var files []string
+	system, _ := filepath.Glob(filepath.Join(systemConfigDir(), "conf.d", "*.yaml"))
+	files = append(files, system...)
+	files = append(files, defaultsPath())
+	user, _ := filepath.Glob(filepath.Join(configDir(), "conf.d", "*.yaml"))
+	return append(files, user...)
+}
+
+// loadDefaults merges the defaults files over the built-in defaults; missing
+// files are not an error. Later files override settings from earlier ones,
+// except sources, which accumulate.
+func loadDefaults() (*Defaults, error) {
+	d := Defaults{AskModel: "haiku", PromptFileThreshold: 64 * 1024, SlowLaunchWarningMS: 1000}
+	for _, path := range defaultsFiles() {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sources := d.Sources
+		d.Sources = nil
+		if err := yaml.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("invalid defaults %s: %w", path, err)
+		}
+		d.Sources = append(sources, d.Sources...)
+	}
+	return &d, nil
+}
+
+// wrapPrompt applies the global prefix/suffix unless the persona opted out.
+// They are shared by every persona, so only $WorkDir is expanded in them.
+func wrapPrompt(prompt string, d *Defaults, cfg *Config, workDir string) string {
+	if cfg.GlobalPrompt != nil && !*cfg.GlobalPrompt {
+		return prompt
+	}
+	if d.GlobalPromptPrefix != "" {
+		prompt = expandWorkDir(d.GlobalPromptPrefix, workDir) + "\n\n" + prompt
+	}
+	if d.GlobalPromptSuffix != "" {
+		prompt = prompt + "\n\n" + expandWorkDir(d.GlobalPromptSuffix, workDir)
+	}
+	return prompt
+}
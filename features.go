@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// knownFeatures lists the config features this version of unum understands.
+// A config declares the features it relies on under features:, so older
+// versions fail loudly instead of silently ignoring settings they don't know.
+// Declaring a feature doesn't enable anything, except that templates, vars,
+// and data-helpers make prompt template errors fatal (see requiresTemplate).
+var knownFeatures = map[string]string{
+	"data-helpers":     "yaml, json, and toJSON prompt template helpers",
+	"directory-layout": "config.yaml + prompt.md + agents/ persona directories",
+	"global-prompt":    "global_prompt opt-out of defaults.yaml prefix/suffix",
+	"isolation":        "isolation: profiles from isolation.yaml",
+	"network":          "network: proxy and API endpoint settings",
+	"project-types":    "project_types: matching for unum here",
+	"sources":          "personas resolved from dir, git, and http sources",
+	"templates":        "prompts that must render as Go templates, never as plain text",
+	"vars":             "typed prompt variables set with --var",
+}
+
+// checkFeatures rejects configs that need features this version lacks.
+func checkFeatures(cfg *Config) error {
+	var unknown []string
+	for _, feature := range cfg.Features {
+		if _, ok := knownFeatures[feature]; !ok {
+			unknown = append(unknown, feature)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("this config uses features not supported by this version of unum: %s (upgrade unum)", strings.Join(unknown, ", "))
+}
+
+// hasFeature reports whether the config declares a feature.
+func hasFeature(cfg *Config, name string) bool {
+	return slices.Contains(cfg.Features, name)
+}
+
+// requiresTemplate reports whether the persona's prompt must render as a Go
+// template. Other prompts fall back to plain $WorkDir/{{.WorkDir}} expansion
+// when they don't parse, so {{ }} text such as Handlebars keeps working.
+func requiresTemplate(cfg *Config) bool {
+	return len(cfg.Vars) > 0 || hasFeature(cfg, "templates") || hasFeature(cfg, "vars") || hasFeature(cfg, "data-helpers")
+}
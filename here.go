@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectMarkers maps files in a project root to a project type, matched
+// against project_types: in persona configs.
+var projectMarkers = []struct {
+	file, kind string
+}{
+	{"go.mod", "go"},
+	{"Cargo.toml", "rust"},
+	{"package.json", "node"},
+	{"pyproject.toml", "python"},
+	{"requirements.txt", "python"},
+	{"Gemfile", "ruby"},
+	{"mix.exs", "elixir"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+	{"flake.nix", "nix"},
+}
+
+// projectFile pins a persona for a project tree. It comes from whatever
+// repository is checked out, so it can only pick a persona, never add args.
+type projectFile struct {
+	Persona string `yaml:"persona"`
+	// Args is read only to warn that it is ignored
+	Args []string `yaml:"args"`
+}
+
+// here picks the most appropriate persona for the current directory and
+// launches it: .unum.yaml, then past sessions here, then project type, then
+// an interactive picker.
+func here(extraArgs []string) error {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	persona, reason, err := pickPersona(workDir)
+	if err != nil {
+		return err
+	}
+	if persona == "" {
+		if persona, err = promptPersona(); err != nil {
+			return err
+		}
+		reason = "picked"
+	}
+	fmt.Fprintf(os.Stderr, "unum here: launching %s (%s)\n", persona, reason)
+
+	if pf, path := findProjectFile(workDir); pf != nil && len(pf.Args) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring args: in %s; put trusted args in the persona config instead\n", path)
+	}
+
+	// Pick up where the last session here left off. Decided before prepare,
+	// so --title labels the continued session rather than a new one
+	if !hasSessionFlag(extraArgs) {
+		_, cfg, err := resolvePersona(persona)
+		if err != nil {
+			return err
+		}
+		sessions, err := personaSessions(cfg, persona, sessionDir(persona, workDir))
+		if err == nil && len(sessions) > 0 {
+			extraArgs = append(extraArgs, "--continue")
+		}
+	}
+
+	l, err := prepare(persona, extraArgs)
+	if err != nil {
+		return err
+	}
+	return l.exec()
+}
+
+func pickPersona(workDir string) (persona, reason string, err error) {
+	pf, path := findProjectFile(workDir)
+	if pf != nil && pf.Persona != "" {
+		if err := checkProjectPersona(pf.Persona); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring persona %q in %s: %v\n", pf.Persona, path, err)
+		} else {
+			return pf.Persona, path, nil
+		}
+	}
+
+	local := dirSource{dir: configDir()}
+	configs := make(map[string]*Config)
+	var personas []string
+	for _, name := range localPersonas() {
+		if cfg, err := local.Lookup(name); err == nil {
+			configs[name] = cfg
+			personas = append(personas, name)
+		}
+	}
+
+	// Persona with the most recent conversation in this directory
+	var latest time.Time
+	for _, name := range personas {
+		sessions, err := personaSessions(configs[name], name, sessionDir(name, workDir))
+		if err == nil && len(sessions) > 0 && sessions[0].Modified.After(latest) {
+			latest, persona = sessions[0].Modified, name
+		}
+	}
+	if persona != "" {
+		return persona, "last used here", nil
+	}
+
+	kinds := projectTypes(workDir)
+	if len(kinds) == 0 {
+		return "", "", nil
+	}
+	var matches []string
+	for _, name := range personas {
+		cfg := configs[name]
+		for _, kind := range kinds {
+			if slices.Contains(cfg.ProjectTypes, kind) {
+				matches = append(matches, name)
+				break
+			}
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], strings.Join(kinds, "/") + " project", nil
+	}
+	return "", "", nil
+}
+
+// personaName matches plain persona names; anything else could reach outside
+// the config dir once joined into a path.
+var personaName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// checkProjectPersona accepts a persona named by a project file only if it is
+// a plain name that the config dir or a configured source resolves, since the
+// file comes from whatever repository is checked out.
+func checkProjectPersona(name string) error {
+	if !personaName.MatchString(name) || reservedNames[name] || commands[name] {
+		return fmt.Errorf("not a persona name")
+	}
+	if slices.Contains(localPersonas(), name) {
+		return nil
+	}
+	defaults, err := loadDefaults()
+	if err != nil {
+		return err
+	}
+	r, err := newResolver(defaults)
+	if err != nil {
+		return err
+	}
+	_, err = r.Resolve(name)
+	return err
+}
+
+// findProjectFile looks for .unum.yaml in workDir and its parents.
+func findProjectFile(workDir string) (*projectFile, string) {
+	for dir := workDir; ; dir = filepath.Dir(dir) {
+		path := filepath.Join(dir, ".unum.yaml")
+		if data, err := os.ReadFile(path); err == nil {
+			var pf projectFile
+			if err := yaml.Unmarshal(data, &pf); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring invalid %s: %v\n", path, err)
+				return nil, ""
+			}
+			return &pf, path
+		}
+		if dir == filepath.Dir(dir) {
+			return nil, ""
+		}
+	}
+}
+
+func projectTypes(workDir string) []string {
+	var kinds []string
+	for _, m := range projectMarkers {
+		if _, err := os.Stat(filepath.Join(workDir, m.file)); err == nil && !slices.Contains(kinds, m.kind) {
+			kinds = append(kinds, m.kind)
+		}
+	}
+	return kinds
+}
+
+func promptPersona() (string, error) {
+	personas := localPersonas()
+	if len(personas) == 0 {
+		return "", fmt.Errorf("no personas found in %s (run 'unum <persona> init' to create one)", configDir())
+	}
+	for i, name := range personas {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, name)
+	}
+	fmt.Fprint(os.Stderr, "Persona: ")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no persona selected")
+	}
+	line = strings.TrimSpace(line)
+	if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(personas) {
+		return personas[n-1], nil
+	}
+	if slices.Contains(personas, line) {
+		return line, nil
+	}
+	return "", fmt.Errorf("unknown persona: %s", line)
+}
+
+// hasSessionFlag reports whether args already pick a session, or run
+// headless where continuing makes no sense.
+func hasSessionFlag(args []string) bool {
+	f := parseSessionFlags(args)
+	return f.idFlag != "" || f.continuing || f.printing
+}
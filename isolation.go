@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsolationProfile is a named security posture shared across personas,
+// defined in isolation.yaml and selected with isolation: <name>.
+type IsolationProfile struct {
+	Env struct {
+		// Keep lists the variables passed through (globs); empty keeps all
+		Keep []string `yaml:"keep"`
+		// Drop lists variables removed even if kept (globs)
+		Drop []string `yaml:"drop"`
+	} `yaml:"env"`
+	// ClaudeConfigDir is inherit (default), persona, or session. Isolated
+	// config dirs hold credentials, so they live in the user's own state
+	// dir, never the (possibly shared) cache dir.
+	ClaudeConfigDir string `yaml:"claude_config_dir"`
+	// Settings is passed to claude via --settings (sandbox, permissions,
+	// network policy, ...)
+	Settings map[string]any `yaml:"settings"`
+}
+
+func isolationPath() string {
+	return filepath.Join(configDir(), "isolation.yaml")
+}
+
+func loadIsolation(name string) (*IsolationProfile, error) {
+	data, err := os.ReadFile(isolationPath())
+	if err != nil {
+		return nil, fmt.Errorf("isolation profile %q: %w", name, err)
+	}
+	var profiles map[string]IsolationProfile
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", isolationPath(), err)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("isolation profile %q not defined in %s", name, isolationPath())
+	}
+	return &profile, nil
+}
+
+// isolate applies the persona's isolation profile to the environment and
+// returns any extra claude args it needs.
+func isolate(cfg *Config, persona, sessDir string, env []string) ([]string, []string, error) {
+	if cfg.Isolation == "" {
+		return env, nil, nil
+	}
+	profile, err := loadIsolation(cfg.Isolation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var scrubbed []string
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if len(profile.Env.Keep) > 0 && !matchAny(profile.Env.Keep, key) {
+			continue
+		}
+		if matchAny(profile.Env.Drop, key) {
+			continue
+		}
+		scrubbed = append(scrubbed, kv)
+	}
+
+	switch profile.ClaudeConfigDir {
+	case "", "inherit":
+	case "persona":
+		scrubbed = setEnv(scrubbed, "CLAUDE_CONFIG_DIR", filepath.Join(stateDir(), "claude", persona, "config"))
+	case "session":
+		scrubbed = setEnv(scrubbed, "CLAUDE_CONFIG_DIR", filepath.Join(stateDir(), "claude", persona, "sessions", filepath.Base(sessDir)))
+	default:
+		return nil, nil, fmt.Errorf("isolation profile %q: invalid claude_config_dir %q (expected inherit, persona, or session)", cfg.Isolation, profile.ClaudeConfigDir)
+	}
+
+	var args []string
+	if len(profile.Settings) > 0 {
+		settings, err := json.Marshal(profile.Settings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("isolation profile %q: invalid settings: %w", cfg.Isolation, err)
+		}
+		args = append(args, "--settings", string(settings))
+	}
+	return scrubbed, args, nil
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnv replaces or adds key in env.
+func setEnv(env []string, key, value string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			out = append(out, kv)
+		}
+	}
+	return append(out, key+"="+value)
+}
+
+// getEnv returns the last value of key in env.
+func getEnv(env []string, key string) string {
+	value := ""
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, key+"="); ok {
+			value = v
+		}
+	}
+	return value
+}
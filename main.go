@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 
@@ -18,10 +18,22 @@ type Agent struct {
 }
 
 type Config struct {
-	Name      string            `yaml:"name"`
-	Prompt    string            `yaml:"prompt"`
-	Args      []string          `yaml:"args"`
-	Agents    map[string]Agent  `yaml:"agents"`
+	Name   string           `yaml:"name"`
+	Prompt string           `yaml:"prompt"`
+	Args   []string         `yaml:"args"`
+	Agents map[string]Agent `yaml:"agents"`
+	// Vars declares prompt variables set with --var key=value
+	Vars map[string]VarSpec `yaml:"vars"`
+	// ProjectTypes lets "unum here" pick this persona for e.g. go or node projects
+	ProjectTypes []string `yaml:"project_types"`
+	// Network sets proxy and API endpoint environment for claude
+	Network NetworkConfig `yaml:"network"`
+	// Isolation names a profile from isolation.yaml
+	Isolation string `yaml:"isolation"`
+	// Features lists the unum features this config relies on
+	Features []string `yaml:"features"`
+	// GlobalPrompt set to false skips the prefix/suffix from defaults.yaml
+	GlobalPrompt *bool `yaml:"global_prompt"`
 }
 
 func configDir() string {
@@ -37,6 +49,15 @@ func configPath(persona string) string {
 }
 
 func cacheDir() string {
+	if shared := sharedRoot(); shared != "" {
+		return shared
+	}
+	return userCacheDir()
+}
+
+// userCacheDir is the current user's own cache, never shared, for cached
+// data other users must not be able to change (e.g. persona sources).
+func userCacheDir() string {
 	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
 		return filepath.Join(xdg, "unum")
 	}
@@ -44,104 +65,249 @@ func cacheDir() string {
 	return filepath.Join(home, ".cache", "unum")
 }
 
+// stateDir holds per-user data that is never shared, even when sessions are
+// (see UNUM_SHARED_DIR).
+func stateDir() string {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "unum")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", "unum")
+}
+
 func sessionDir(persona, workDir string) string {
 	// Convert /home/dev/Projects/foo to home-dev-Projects-foo
 	dasherized := strings.ReplaceAll(strings.TrimPrefix(workDir, "/"), "/", "-")
 	return filepath.Join(cacheDir(), persona, dasherized)
 }
 
-func loadConfig(persona string) (*Config, error) {
-	data, err := os.ReadFile(configPath(persona))
+// personaDir is the directory-based layout: config.yaml, prompt.md, agents/
+func personaDir(persona string) string {
+	return filepath.Join(configDir(), persona)
+}
+
+// commands are unum's own subcommands, which can't be used as persona names.
+var commands = map[string]bool{
+	"adopt":         true,
+	"bench":         true,
+	"help":          true,
+	"here":          true,
+	"release-notes": true,
+	"report":        true,
+}
+
+// reservedNames are config dir files that aren't personas.
+var reservedNames = map[string]bool{
+	"defaults":  true,
+	"isolation": true,
+}
+
+// localPersonas lists the personas in the config dir, in either layout.
+func localPersonas() []string {
+	entries, err := os.ReadDir(configDir())
 	if err != nil {
-		return nil, fmt.Errorf("config not found: %s (run 'unum %s init' to create)", configPath(persona), persona)
+		return nil
 	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case e.IsDir():
+			if _, err := os.Stat(filepath.Join(configDir(), name, "config.yaml")); err == nil {
+				names = append(names, name)
+			}
+		case strings.HasSuffix(name, ".yaml") && !reservedNames[strings.TrimSuffix(name, ".yaml")]:
+			names = append(names, strings.TrimSuffix(name, ".yaml"))
+		}
 	}
-	return &cfg, nil
+	return names
 }
 
-func writeTemplate(persona string) error {
-	path := configPath(persona)
-
-	if _, err := os.Stat(path); err == nil {
-		return fmt.Errorf("config already exists: %s", path)
+// loadPersonaFiles fills in the prompt and agents from a persona directory.
+// Values set in config.yaml take precedence.
+func loadPersonaFiles(dir string, cfg *Config) error {
+	if cfg.Prompt == "" {
+		data, err := os.ReadFile(filepath.Join(dir, "prompt.md"))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		cfg.Prompt = string(data)
 	}
 
-	if err := os.MkdirAll(configDir(), 0755); err != nil {
+	paths, err := filepath.Glob(filepath.Join(dir, "agents", "*.md"))
+	if err != nil {
 		return err
 	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		if _, ok := cfg.Agents[name]; ok {
+			continue
+		}
+		agent, err := loadAgentFile(path)
+		if err != nil {
+			return fmt.Errorf("invalid agent %s: %w", path, err)
+		}
+		if cfg.Agents == nil {
+			cfg.Agents = make(map[string]Agent)
+		}
+		cfg.Agents[name] = *agent
+	}
+	return nil
+}
 
-	template := fmt.Sprintf(`name: %s
-prompt: |
-  # %s
-
-  You are %s. Define your persona here.
+// loadAgentFile parses a markdown agent: YAML frontmatter plus prompt body.
+func loadAgentFile(path string) (*Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-  ## Working Directory
+	var agent Agent
+	body := string(data)
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		front, after, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			return nil, fmt.Errorf("unterminated frontmatter")
+		}
+		if err := yaml.Unmarshal([]byte(front), &agent); err != nil {
+			return nil, err
+		}
+		body = after
+	}
+	if agent.Prompt == "" {
+		agent.Prompt = strings.TrimSpace(body)
+	}
+	return &agent, nil
+}
 
-  Your working directory is {{.WorkDir}}.
-  Before your first tool use, run: cd {{.WorkDir}}
-args:
-  - "--model"
-  - "sonnet"
-# agents:
-#   worker:
-#     description: "A helper agent"
-#     prompt: "You are a helpful assistant"
-`, persona, persona, persona)
+func writeTemplate(persona string) error {
+	dir := personaDir(persona)
+	for _, path := range []string{dir, configPath(persona)} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("config already exists: %s", path)
+		}
+	}
 
-	if err := os.WriteFile(path, []byte(template), 0644); err != nil {
+	created, err := renderInit(dir, persona)
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Created %s\n", path)
+	for _, path := range created {
+		fmt.Printf("Created %s\n", path)
+	}
 	return nil
 }
 
+// launch is a fully resolved claude invocation.
+type launch struct {
+	Path string // claude binary
+	Args []string
+	Dir  string // session directory claude runs in
+	Env  []string
+}
+
 func invoke(persona string, extraArgs []string) error {
-	cfg, err := loadConfig(persona)
+	l, err := prepare(persona, extraArgs)
 	if err != nil {
 		return err
 	}
+	return l.exec()
+}
+
+func (l *launch) exec() error {
+	// Change to session directory and exec claude
+	if err := os.Chdir(l.Dir); err != nil {
+		return err
+	}
+
+	// Exec replaces the current process
+	return syscall.Exec(l.Path, append([]string{"claude"}, l.Args...), l.Env)
+}
+
+// prepare resolves the persona config into a claude invocation without
+// running it.
+func prepare(persona string, extraArgs []string) (*launch, error) {
+	defaults, cfg, err := resolvePersona(persona)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only clashes with the flags unum sets are fatal; anything else is
+	// claude's to judge
+	if conflicts, _, _ := claudeBackend.validateArgs(cfg.Args); len(conflicts) > 0 {
+		return nil, fmt.Errorf("invalid args: %s (run 'unum %s validate' for details)", conflicts[0], persona)
+	}
+
+	given, extraArgs, err := splitVars(extraArgs)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := resolveVars(cfg.Vars, given)
+	if err != nil {
+		return nil, err
+	}
+	title, description, extraArgs, err := splitSessionLabel(extraArgs)
+	if err != nil {
+		return nil, err
+	}
+	verbose := os.Getenv("UNUM_VERBOSE") != ""
 
 	// Get current working directory
 	workDir, err := os.Getwd()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create persistent session directory (enables --continue and --resume)
+	done := timings.begin("session dir")
 	sessDir := sessionDir(persona, workDir)
-	if err := os.MkdirAll(sessDir, 0755); err != nil {
-		return err
+	if err := prepareSessionDir(sessDir); err != nil {
+		return nil, err
+	}
+	done()
+
+	// Render template variables in prompt, then add the global prefix/suffix
+	done = timings.begin("render prompt")
+	prompt, err := renderPrompt(cfg.Prompt, promptData{
+		Persona: persona,
+		WorkDir: workDir,
+		Vars:    vars,
+	}, requiresTemplate(cfg))
+	if err != nil {
+		return nil, err
 	}
+	prompt = wrapPrompt(prompt, defaults, cfg, workDir)
+	done()
 
-	// Expand template variables in prompt
-	prompt := os.Expand(cfg.Prompt, func(key string) string {
-		switch key {
-		case "WorkDir":
-			return workDir
-		default:
-			return "$" + key // preserve unknown variables
-		}
-	})
-	// Also handle {{.WorkDir}} style
-	prompt = replaceTemplate(prompt, "{{.WorkDir}}", workDir)
+	// Apply the isolation profile, if any
+	done = timings.begin("isolation")
+	env, isolationArgs, err := isolate(cfg, persona, sessDir, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	env = cfg.Network.apply(env)
+	done()
 
-	// Build claude args
-	args := []string{
-		"--system-prompt", prompt,
-		"--add-dir", workDir,
+	// Label the session if --title or --description was given
+	extraArgs, err = labelLaunch(sessDir, env, title, description, extraArgs)
+	if err != nil {
+		return nil, err
 	}
 
+	// Build claude args; huge prompts go through a file
+	args, err := claudeBackend.promptArgs(prompt, sessDir, defaults.PromptFileThreshold, slices.Concat(cfg.Args, extraArgs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pass prompt: %w", err)
+	}
+	args = append(args, "--add-dir", workDir)
+	args = append(args, isolationArgs...)
+
 	// Add agents if defined
 	if len(cfg.Agents) > 0 {
 		agentsJSON, err := json.Marshal(cfg.Agents)
 		if err != nil {
-			return fmt.Errorf("failed to marshal agents: %w", err)
+			return nil, fmt.Errorf("failed to marshal agents: %w", err)
 		}
 		args = append(args, "--agents", string(agentsJSON))
 	}
@@ -153,39 +319,46 @@ func invoke(persona string, extraArgs []string) error {
 	args = append(args, extraArgs...)
 
 	// Find claude binary
-	claudePath, err := exec.LookPath("claude")
+	done = timings.begin("find claude")
+	claudePath, err := findClaude()
 	if err != nil {
-		return fmt.Errorf("claude not found in PATH")
+		return nil, err
 	}
+	done()
 
-	// Change to session directory and exec claude
-	if err := os.Chdir(sessDir); err != nil {
-		return err
-	}
+	timings.report(verbose, defaults.SlowLaunchWarningMS)
 
-	// Exec replaces the current process
-	return syscall.Exec(claudePath, append([]string{"claude"}, args...), os.Environ())
+	return &launch{
+		Path: claudePath,
+		Args: args,
+		Dir:  sessDir,
+		Env:  env,
+	}, nil
 }
 
-func replaceTemplate(s, old, new string) string {
-	result := s
-	for {
-		i := indexOf(result, old)
-		if i < 0 {
-			break
-		}
-		result = result[:i] + new + result[i+len(old):]
+// resolvePersona loads the defaults and the persona's config.
+func resolvePersona(persona string) (*Defaults, *Config, error) {
+	done := timings.begin("load defaults")
+	defaults, err := loadDefaults()
+	if err != nil {
+		return nil, nil, err
 	}
-	return result
-}
+	done()
 
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
+	done = timings.begin("resolve " + persona)
+	r, err := newResolver(defaults)
+	if err != nil {
+		return nil, nil, err
 	}
-	return -1
+	cfg, err := r.Resolve(persona)
+	if err != nil {
+		return nil, nil, err
+	}
+	done()
+	if err := checkFeatures(cfg); err != nil {
+		return nil, nil, err
+	}
+	return defaults, cfg, nil
 }
 
 func usage() {
@@ -194,10 +367,87 @@ func usage() {
 Usage:
   unum <persona> [flags...]   Launch claude with the specified persona
   unum <persona> init         Create a template config for the persona
-
-Flags are passed through to claude (e.g., --continue, --resume, -p "prompt")
-
-Config files are stored in ~/.config/unum/<persona>.yaml
+  unum <persona> validate     Check the config, including args: against the
+                              flags claude accepts
+  unum here [flags...]        Launch the best persona for the current directory:
+                              .unum.yaml (persona: name), the persona last used
+                              here, a persona whose project_types match, or a
+                              picker; continues the latest session if any
+  unum <persona> ask "<question>" [flags...]
+                              Ask a quick single-turn question with a cheap
+                              model, forking the project's latest session
+  unum <persona> sessions list
+                              List saved sessions for the current directory
+  unum <persona> sessions rename <id> <title> [--description text]
+                              Label a session
+  unum <persona> sessions export [--format jsonl] [--session id] [--tools flatten|drop]
+                              Write transcripts from every directory the
+                              persona was used in as role/content JSONL
+  unum bench <persona> -p "<task>" [-n 5] [flags...]
+                              Run the task headless n times and report latency,
+                              token usage, and cost
+  unum adopt [--rc file]... [--rewrite] [--dry-run]
+                              Convert claude aliases in shell rc files into
+                              personas; --rewrite points the aliases at unum
+  unum release-notes [--since tag] [--persona name] [--output file] [--stdout]
+                              Draft a CHANGELOG section from the commits since
+                              the last tag using a persona headless
+  unum report [-o file]       Write a redacted diagnostic bundle (offline) to
+                              attach to bug reports
+
+Flags are passed through to claude (e.g., --continue, --resume, -p "prompt"),
+except --var key=value, which sets a prompt variable ({{.Vars.key}}) declared
+in the config, e.g. vars: { language: { required: true, enum: [go, rust] } },
+and --title/--description, which label the session in "sessions list"
+
+Config files are stored in ~/.config/unum/<persona>/:
+  config.yaml       name, args, and other settings
+  prompt.md         the system prompt, rendered as a Go template: {{.WorkDir}},
+                    {{.Persona}}, {{ json "package.json" "scripts" }} and
+                    {{ yaml "config/app.yaml" | toJSON }} are expanded. A prompt
+                    that doesn't parse as a template (e.g. Handlebars) is used
+                    as is, unless the config lists features: [templates]
+  agents/<name>.md  subagents (YAML frontmatter with description, then prompt)
+A single-file ~/.config/unum/<persona>.yaml is also supported.
+Route a persona's traffic with network: (set as environment for claude):
+  network: { https_proxy: http://proxy:3128, no_proxy: localhost,
+             anthropic_base_url: https://gateway.example.com }
+Isolation profiles shared across personas live in ~/.config/unum/isolation.yaml
+and are selected with "isolation: <name>":
+  strict:
+    env: { keep: [PATH, HOME, TERM, LANG], drop: ["*_TOKEN"] }
+    claude_config_dir: persona   # inherit, persona, or session (kept in
+                                 # ~/.local/state/unum/claude)
+    settings: { sandbox: { enabled: true } }   # passed via --settings
+List the features a config relies on (e.g. features: [vars]) so older versions
+of unum refuse it instead of ignoring settings they don't understand.
+
+Settings shared by all personas live in ~/.config/unum/defaults.yaml:
+  global_prompt_prefix: text prepended to every persona prompt
+  global_prompt_suffix: text appended to every persona prompt
+                        (a persona can opt out with "global_prompt: false")
+  ask_model:            model used by "unum <persona> ask" (default: haiku)
+  release_notes_persona: persona used by "unum release-notes"
+  prompt_file_threshold: prompts larger than this many bytes are passed to
+                        claude by file in print mode (-p) (default: 65536,
+                        0 = always inline)
+  slow_launch_warning_ms: warn when startup takes longer (default: 1000, 0 = off);
+                        set UNUM_VERBOSE=1 to see per-step timings
+  sources:              extra places to look up personas, after the config dir:
+    - dir: ~/team-personas
+    - git: https://example.com/personas.git
+      path: personas
+    - http: https://example.com/personas   (fetches <url>/<persona>.yaml)
+Fragments in /etc/unum/conf.d/*.yaml (merged before defaults.yaml) and
+~/.config/unum/conf.d/*.yaml (merged after) apply in lexical order; later files
+override earlier settings, while sources accumulate.
+
+Environment:
+  UNUM_CLAUDE_PATH            Use this claude binary instead of searching PATH
+  UNUM_SYSTEM_CONFIG_DIR      Use instead of /etc/unum for system-wide conf.d
+  UNUM_SHARED_DIR             Keep sessions in a group-writable directory shared
+                              with other users (e.g. for pair-programming)
+  UNUM_VERBOSE                Print how long each startup step took
 `)
 	os.Exit(1)
 }
@@ -209,11 +459,80 @@ func main() {
 
 	persona := os.Args[1]
 
+	if persona == "release-notes" {
+		if err := releaseNotes(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if persona == "adopt" {
+		if err := adopt(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if persona == "here" {
+		if err := here(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if persona == "report" {
+		if err := report(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if persona == "bench" {
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Handle help flags
 	if persona == "-h" || persona == "--help" || persona == "help" {
 		usage()
 	}
 
+	if reservedNames[persona] {
+		fmt.Fprintf(os.Stderr, "Error: %q is reserved for %s.yaml in %s\n", persona, persona, configDir())
+		os.Exit(1)
+	}
+
+	if len(os.Args) >= 3 && os.Args[2] == "ask" {
+		if err := ask(persona, os.Args[3:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) >= 3 && os.Args[2] == "sessions" {
+		if err := sessionsCmd(persona, os.Args[3:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) >= 3 && os.Args[2] == "validate" {
+		if err := validate(persona); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(os.Args) >= 3 && os.Args[2] == "init" {
 		if err := writeTemplate(persona); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
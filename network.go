@@ -0,0 +1,31 @@
+package main
+
+import "strings"
+
+// NetworkConfig routes a persona's traffic, e.g. through a corporate gateway.
+type NetworkConfig struct {
+	HTTPSProxy       string `yaml:"https_proxy"`
+	HTTPProxy        string `yaml:"http_proxy"`
+	NoProxy          string `yaml:"no_proxy"`
+	AnthropicBaseURL string `yaml:"anthropic_base_url"`
+}
+
+// apply sets the configured values in env for the child process.
+func (n NetworkConfig) apply(env []string) []string {
+	for _, v := range []struct{ key, value string }{
+		{"HTTPS_PROXY", n.HTTPSProxy},
+		{"HTTP_PROXY", n.HTTPProxy},
+		{"NO_PROXY", n.NoProxy},
+	} {
+		if v.value == "" {
+			continue
+		}
+		// Set both spellings so an inherited lowercase variable can't win
+		env = setEnv(env, v.key, v.value)
+		env = setEnv(env, strings.ToLower(v.key), v.value)
+	}
+	if n.AnthropicBaseURL != "" {
+		env = setEnv(env, "ANTHROPIC_BASE_URL", n.AnthropicBaseURL)
+	}
+	return env
+}
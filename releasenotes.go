@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// releaseNotes drafts a CHANGELOG section from the commits since a tag using
+// a persona in headless mode:
+// unum release-notes [--since tag] [--persona name] [--output file] [--stdout]
+func releaseNotes(argv []string) error {
+	since, persona, output, toStdout := "", "", "CHANGELOG.md", false
+	for i := 0; i < len(argv); i++ {
+		switch argv[i] {
+		case "--stdout":
+			toStdout = true
+			continue
+		case "--since", "--persona", "--output":
+		default:
+			return fmt.Errorf("usage: unum release-notes [--since tag] [--persona name] [--output file] [--stdout]")
+		}
+		if i+1 >= len(argv) {
+			return fmt.Errorf("%s requires a value", argv[i])
+		}
+		switch argv[i] {
+		case "--since":
+			since = argv[i+1]
+		case "--persona":
+			persona = argv[i+1]
+		case "--output":
+			output = argv[i+1]
+		}
+		i++
+	}
+
+	if persona == "" {
+		defaults, err := loadDefaults()
+		if err != nil {
+			return err
+		}
+		persona = defaults.ReleaseNotesPersona
+	}
+	if persona == "" {
+		return fmt.Errorf("no persona for release notes (pass --persona or set release_notes_persona in %s)", defaultsPath())
+	}
+
+	if since == "" {
+		out, err := exec.Command("git", "describe", "--tags", "--abbrev=0").Output()
+		if err == nil {
+			since = strings.TrimSpace(string(out))
+		}
+	}
+	changes, err := collectChanges(since)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("no commits since %s", since)
+	}
+
+	rangeDesc := "the start of the project"
+	if since != "" {
+		rangeDesc = since
+	}
+	// The change list goes on stdin: the whole history can be far larger
+	// than a single argument may be (see maxArgLen)
+	prompt := fmt.Sprintf(`Draft a CHANGELOG section for the changes since %s, listed on stdin.
+Group related changes under Added, Changed, Fixed, and Removed as appropriate,
+write for users rather than maintainers, and omit purely internal changes.
+Start with the heading "## Unreleased" and output only the markdown section.`, rangeDesc)
+
+	// Like bench, the run isn't saved as a session of the persona
+	l, err := prepare(persona, []string{"--no-session-persistence", "-p", prompt})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(l.Path, l.Args...)
+	cmd.Dir = l.Dir
+	cmd.Env = l.Env
+	cmd.Stdin = strings.NewReader(strings.Join(changes, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", persona, err)
+	}
+	section := strings.TrimSpace(string(out)) + "\n"
+
+	if toStdout {
+		fmt.Print(section)
+		return nil
+	}
+	if err := prependChangelog(output, section); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote draft release notes to %s\n", output)
+	return nil
+}
+
+// collectChanges lists commit subjects since the ref, using pull request
+// titles for merge commits.
+func collectChanges(since string) ([]string, error) {
+	args := []string{"log", "--format=%h%x00%P%x00%s%x00%b%x1e"}
+	if since != "" {
+		args = append(args, since+"..HEAD")
+	}
+	out, err := exec.Command("git", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("git log: %s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var changes []string
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		hash, parents, subject := fields[0], fields[1], fields[2]
+		body := ""
+		if len(fields) == 4 {
+			body = fields[3]
+		}
+
+		if strings.Contains(parents, " ") { // merge commit
+			if !strings.HasPrefix(subject, "Merge pull request") {
+				continue
+			}
+			title, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+			if title == "" {
+				continue
+			}
+			if words := strings.Fields(subject); len(words) > 3 {
+				title = fmt.Sprintf("%s (%s)", title, words[3])
+			}
+			subject = title
+		}
+		changes = append(changes, fmt.Sprintf("- %s [%s]", subject, hash))
+	}
+	return changes, nil
+}
+
+// prependChangelog inserts section after the file's top-level title, or at
+// the start when there is none.
+func prependChangelog(path, section string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	existing := string(data)
+
+	var result string
+	switch {
+	case existing == "":
+		result = "# Changelog\n\n" + section
+	case strings.HasPrefix(existing, "# "):
+		title, rest, _ := strings.Cut(existing, "\n")
+		result = title + "\n\n" + section + "\n" + strings.TrimLeft(rest, "\n")
+	default:
+		result = section + "\n" + existing
+	}
+	return os.WriteFile(path, []byte(result), 0644)
+}
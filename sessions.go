@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sessionMetaFile holds titles and descriptions, keyed by session id.
+const sessionMetaFile = ".unum-sessions.json"
+
+// sessionMeta is the human label for a session.
+type sessionMeta struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Updated     time.Time `json:"updated"`
+}
+
+// transcriptEntry is one line of a claude transcript (.jsonl).
+type transcriptEntry struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId"`
+	Timestamp string `json:"timestamp"`
+	IsMeta    bool   `json:"isMeta"`
+	Message   *struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type contentBlock struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Content json.RawMessage `json:"content"`
+}
+
+// exportRecord is the normalized role/content form written by export.
+type exportRecord struct {
+	Session   string `json:"session"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+}
+
+// sessionInfo describes one saved claude session for a persona.
+type sessionInfo struct {
+	ID       string
+	Path     string
+	Modified time.Time
+}
+
+func sessionsCmd(persona string, argv []string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("usage: unum %s sessions list|export|rename [args...]", persona)
+	}
+
+	_, cfg, err := resolvePersona(persona)
+	if err != nil {
+		return err
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	sessDir := sessionDir(persona, workDir)
+
+	switch argv[0] {
+	case "list":
+		sessions, err := personaSessions(cfg, persona, sessDir)
+		if err != nil {
+			return err
+		}
+		return printSessions(sessDir, sessions)
+	case "export":
+		// Every directory the persona was used in, not just this one
+		dirs, err := os.ReadDir(filepath.Join(cacheDir(), persona))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		var sessions []sessionInfo
+		for _, d := range dirs {
+			if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+				continue
+			}
+			found, err := personaSessions(cfg, persona, filepath.Join(cacheDir(), persona, d.Name()))
+			if err != nil {
+				return err
+			}
+			sessions = append(sessions, found...)
+		}
+		sortSessions(sessions)
+		return exportSessions(sessions, argv[1:])
+	case "rename":
+		sessions, err := personaSessions(cfg, persona, sessDir)
+		if err != nil {
+			return err
+		}
+		return renameSession(sessDir, sessions, argv[1:])
+	default:
+		return fmt.Errorf("unknown sessions command: %s", argv[0])
+	}
+}
+
+// personaSessions lists the sessions claude saved for a session dir, which
+// depends on the persona's isolation profile.
+func personaSessions(cfg *Config, persona, sessDir string) ([]sessionInfo, error) {
+	env, _, err := isolate(cfg, persona, sessDir, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	return listSessions(claudeProjectDir(sessDir, env))
+}
+
+// listSessions returns the transcripts in a claude project dir, newest first.
+func listSessions(projectDir string) ([]sessionInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []sessionInfo
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sessionInfo{
+			ID:       strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+			Path:     path,
+			Modified: info.ModTime(),
+		})
+	}
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+// sortSessions orders sessions newest first.
+func sortSessions(sessions []sessionInfo) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+}
+
+func printSessions(sessDir string, sessions []sessionInfo) error {
+	if len(sessions) == 0 {
+		fmt.Println("No sessions for this directory")
+		return nil
+	}
+	meta, err := loadSessionMeta(sessDir)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		label := meta[s.ID].Title
+		if label == "" {
+			// Fall back to the first prompt
+			readTranscript(s, false, func(r exportRecord) bool {
+				if r.Role == "user" {
+					label = strings.Join(strings.Fields(r.Content), " ")
+					return false
+				}
+				return true
+			})
+			if runes := []rune(label); len(runes) > 60 {
+				label = string(runes[:57]) + "..."
+			}
+		}
+		fmt.Printf("%s  %s  %s\n", s.ID, s.Modified.Format("2006-01-02 15:04"), label)
+		if desc := meta[s.ID].Description; desc != "" {
+			fmt.Printf("    %s\n", desc)
+		}
+	}
+	return nil
+}
+
+// renameSession sets a session's title: rename <id> <title> [--description d]
+func renameSession(sessDir string, sessions []sessionInfo, argv []string) error {
+	if len(argv) != 2 && !(len(argv) == 4 && argv[2] == "--description") {
+		return fmt.Errorf("usage: sessions rename <id> <title> [--description text]")
+	}
+	id := argv[0]
+	found := false
+	for _, s := range sessions {
+		found = found || s.ID == id
+	}
+	if !found {
+		return fmt.Errorf("session not found: %s", id)
+	}
+	description := ""
+	if len(argv) == 4 {
+		description = argv[3]
+	}
+	return labelSession(sessDir, id, argv[1], description)
+}
+
+func loadSessionMeta(sessDir string) (map[string]sessionMeta, error) {
+	meta := make(map[string]sessionMeta)
+	data, err := os.ReadFile(filepath.Join(sessDir, sessionMetaFile))
+	if os.IsNotExist(err) {
+		return meta, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", sessionMetaFile, err)
+	}
+	return meta, nil
+}
+
+// labelSession records a title and/or description for a session; empty
+// values keep what was there.
+func labelSession(sessDir, id, title, description string) error {
+	meta, err := loadSessionMeta(sessDir)
+	if err != nil {
+		return err
+	}
+	m := meta[id]
+	if title != "" {
+		m.Title = title
+	}
+	if description != "" {
+		m.Description = description
+	}
+	m.Updated = time.Now()
+	meta[id] = m
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Other users label sessions in a shared dir too (see prepareSessionDir)
+	if sharedRoot() != "" {
+		setGroupUmask()
+	}
+	if err := os.MkdirAll(sessDir, 0775); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(sessDir, sessionMetaFile), append(data, '\n'), 0664)
+}
+
+// splitSessionLabel pulls --title and --description out of the args meant
+// for claude.
+func splitSessionLabel(args []string) (title, description string, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if flag != "--title" && flag != "--description" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", "", nil, fmt.Errorf("%s requires a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+		if flag == "--title" {
+			title = value
+		} else {
+			description = value
+		}
+	}
+	return title, description, rest, nil
+}
+
+// labelLaunch attaches a title/description to the session claude is about to
+// run, returning the args with a --session-id added when claude will start a
+// new session (a fresh launch, or a --fork-session).
+func labelLaunch(sessDir string, env []string, title, description string, args []string) ([]string, error) {
+	if title == "" && description == "" {
+		return args, nil
+	}
+
+	f := parseSessionFlags(args)
+	if f.idFlag != "" && f.id == "" {
+		return nil, fmt.Errorf("--title needs a session id with %s (or set it later with: sessions rename <id> <title>)", f.idFlag)
+	}
+	id := f.id
+
+	switch {
+	case f.forking && (f.continuing || id != ""):
+		id = ""
+	case f.continuing && id == "":
+		sessions, err := listSessions(claudeProjectDir(sessDir, env))
+		if err != nil {
+			return nil, err
+		}
+		if len(sessions) == 0 {
+			return nil, fmt.Errorf("--title with --continue needs an existing session here; drop --continue to start a new one")
+		}
+		id = sessions[0].ID
+	}
+	if id == "" {
+		var err error
+		if id, err = newSessionID(); err != nil {
+			return nil, err
+		}
+		args = append(args, "--session-id", id)
+	}
+	return args, labelSession(sessDir, id, title, description)
+}
+
+// sessionFlags is what claude's args say about which session to run.
+type sessionFlags struct {
+	idFlag     string // --session-id, -r, or --resume, if given
+	id         string // its value; "" for a bare --resume (session picker)
+	continuing bool
+	forking    bool
+	printing   bool
+}
+
+// parseSessionFlags reads the session flags in claude args, in both the
+// "--flag value" and "--flag=value" forms.
+func parseSessionFlags(args []string) sessionFlags {
+	var f sessionFlags
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		switch flag {
+		case "--session-id", "-r", "--resume":
+			if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				value = args[i]
+			}
+			f.idFlag, f.id = flag, value
+		case "-c", "--continue":
+			f.continuing = true
+		case "--fork-session":
+			f.forking = true
+		case "-p", "--print":
+			f.printing = true
+		}
+	}
+	return f
+}
+
+// newSessionID returns a random (v4) UUID, the format claude expects.
+func newSessionID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// exportSessions writes transcripts as normalized JSONL to stdout:
+// unum <persona> sessions export [--format jsonl] [--session id] [--tools flatten|drop]
+func exportSessions(sessions []sessionInfo, argv []string) error {
+	only := ""
+	includeTools := true
+	for i := 0; i < len(argv); i++ {
+		if i+1 >= len(argv) {
+			return fmt.Errorf("%s requires a value", argv[i])
+		}
+		switch argv[i] {
+		case "--format":
+			if argv[i+1] != "jsonl" {
+				return fmt.Errorf("unsupported format: %s (supported: jsonl)", argv[i+1])
+			}
+		case "--session":
+			only = argv[i+1]
+		case "--tools":
+			switch argv[i+1] {
+			case "flatten":
+				includeTools = true
+			case "drop":
+				includeTools = false
+			default:
+				return fmt.Errorf("invalid --tools %s (expected flatten or drop)", argv[i+1])
+			}
+		default:
+			return fmt.Errorf("unknown flag: %s", argv[i])
+		}
+		i++
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+
+	found := false
+	// Oldest first so the stream reads chronologically
+	for i := len(sessions) - 1; i >= 0; i-- {
+		s := sessions[i]
+		if only != "" && s.ID != only {
+			continue
+		}
+		found = true
+		var encErr error
+		err := readTranscript(s, includeTools, func(r exportRecord) bool {
+			encErr = enc.Encode(r)
+			return encErr == nil
+		})
+		if err != nil {
+			return err
+		}
+		if encErr != nil {
+			return encErr
+		}
+	}
+	if only != "" && !found {
+		return fmt.Errorf("session not found: %s", only)
+	}
+	return nil
+}
+
+// readTranscript calls fn for each normalized message until it returns false.
+// Tool calls are flattened to text, or skipped when includeTools is false.
+func readTranscript(s sessionInfo, includeTools bool, fn func(exportRecord) bool) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry transcriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // tolerate partial or unknown lines
+		}
+		if entry.Message == nil || entry.IsMeta || (entry.Type != "user" && entry.Type != "assistant") {
+			continue
+		}
+		for _, r := range normalizeMessage(entry, includeTools) {
+			r.Session = s.ID
+			if !fn(r) {
+				return nil
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func normalizeMessage(entry transcriptEntry, includeTools bool) []exportRecord {
+	role := entry.Message.Role
+	record := func(role, content string) exportRecord {
+		return exportRecord{Timestamp: entry.Timestamp, Role: role, Content: content}
+	}
+
+	var text string
+	if err := json.Unmarshal(entry.Message.Content, &text); err == nil {
+		if text == "" {
+			return nil
+		}
+		return []exportRecord{record(role, text)}
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+		return nil
+	}
+	// Consecutive text blocks are joined; tool records keep their place
+	// between them
+	var records []exportRecord
+	var texts []string
+	flush := func() {
+		if len(texts) > 0 {
+			records = append(records, record(role, strings.Join(texts, "\n")))
+			texts = nil
+		}
+	}
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			texts = append(texts, b.Text)
+		case "tool_use":
+			if includeTools {
+				flush()
+				records = append(records, record("assistant", fmt.Sprintf("[tool_use %s] %s", b.Name, b.Input)))
+			}
+		case "tool_result":
+			if includeTools {
+				flush()
+				records = append(records, record("tool", blockText(b.Content)))
+			}
+		}
+	}
+	flush()
+	return records
+}
+
+// blockText flattens tool_result content, which is a string or text blocks.
+func blockText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return string(raw)
+	}
+	var texts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ownerFile records who created a session directory in shared mode.
+const ownerFile = ".unum-owner"
+
+type sessionOwner struct {
+	User    string    `json:"user"`
+	UID     int       `json:"uid"`
+	Created time.Time `json:"created"`
+}
+
+// sharedRoot returns the group-writable state root used for pair-programming
+// on a shared dev box, or "" when sessions are private to the current user.
+func sharedRoot() string {
+	return os.Getenv("UNUM_SHARED_DIR")
+}
+
+// prepareSessionDir creates the session directory. In shared mode it is made
+// group-writable and stamped with ownership metadata, and joining a directory
+// created by someone else prints a warning. Transcripts still live in each
+// user's own claude config dir, so only the working files are shared.
+func prepareSessionDir(sessDir string) error {
+	if sharedRoot() == "" {
+		return os.MkdirAll(sessDir, 0755)
+	}
+
+	// Group members must be able to write everything we and claude create
+	setGroupUmask()
+
+	if err := os.MkdirAll(sessDir, os.ModeSetgid|0775); err != nil {
+		return err
+	}
+
+	owner, err := readOwner(sessDir)
+	if os.IsNotExist(err) {
+		// Mkdir may drop the setgid bit; fix it up on directories we own
+		os.Chmod(sessDir, os.ModeSetgid|0775)
+		return writeOwner(sessDir)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid session owner metadata: %w", err)
+	}
+
+	if owner.UID != os.Getuid() {
+		if !canWrite(sessDir) {
+			return fmt.Errorf("session dir %s is owned by %s and not group-writable (ask them to run: chmod -R g+w %s)", sessDir, owner.User, sessDir)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: session directory was created by %s on %s; their conversations stay in their own ~/.claude and can't be resumed here\n", owner.User, owner.Created.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func readOwner(sessDir string) (*sessionOwner, error) {
+	data, err := os.ReadFile(filepath.Join(sessDir, ownerFile))
+	if err != nil {
+		return nil, err
+	}
+	var owner sessionOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+func writeOwner(sessDir string) error {
+	name := strconv.Itoa(os.Getuid())
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	data, err := json.MarshalIndent(sessionOwner{
+		User:    name,
+		UID:     os.Getuid(),
+		Created: time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(sessDir, ownerFile), append(data, '\n'), 0664)
+}
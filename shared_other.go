@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// setGroupUmask is a no-op where there is no umask; permissions come from
+// the shared directory's ACLs instead.
+func setGroupUmask() {}
+
+// canWrite reports whether the current user may write to dir.
+func canWrite(dir string) bool {
+	f, err := os.CreateTemp(dir, ".unum-write-check-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
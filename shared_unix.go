@@ -0,0 +1,16 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// setGroupUmask makes files created by unum, and by the claude it execs,
+// group-writable. It is deliberately not restored.
+func setGroupUmask() {
+	syscall.Umask(0002)
+}
+
+// canWrite reports whether the current user may write to dir.
+func canWrite(dir string) bool {
+	return syscall.Access(dir, 0x2) == nil // W_OK
+}
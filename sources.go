@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// errPersonaNotFound is returned by a source that doesn't have the persona.
+var errPersonaNotFound = errors.New("persona not found")
+
+// gitRefreshInterval limits how often git sources are pulled.
+const gitRefreshInterval = time.Hour
+
+// source is a place personas can be loaded from.
+type source interface {
+	Lookup(name string) (*Config, error)
+	String() string
+}
+
+// SourceSpec configures an additional persona source in defaults.yaml.
+// Exactly one of Dir, Git, or HTTP is set.
+type SourceSpec struct {
+	Dir  string `yaml:"dir"`
+	Git  string `yaml:"git"`
+	Path string `yaml:"path"` // subdirectory within a git source
+	HTTP string `yaml:"http"`
+}
+
+// resolver looks personas up in each source in order.
+type resolver struct {
+	sources []source
+}
+
+// newResolver searches the user config dir first, then configured sources.
+func newResolver(d *Defaults) (*resolver, error) {
+	r := &resolver{sources: []source{dirSource{dir: configDir()}}}
+	for _, spec := range d.Sources {
+		src, err := spec.source()
+		if err != nil {
+			return nil, err
+		}
+		r.sources = append(r.sources, src)
+	}
+	return r, nil
+}
+
+func (r *resolver) Resolve(name string) (*Config, error) {
+	for _, src := range r.sources {
+		cfg, err := src.Lookup(name)
+		if errors.Is(err, errPersonaNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+		return cfg, nil
+	}
+	return nil, fmt.Errorf("config not found: %s (run 'unum %s init' to create)", personaDir(name), name)
+}
+
+func (spec SourceSpec) source() (source, error) {
+	switch {
+	case spec.Dir != "":
+		return dirSource{dir: expandHome(spec.Dir)}, nil
+	case spec.Git != "":
+		return gitSource{url: spec.Git, path: spec.Path}, nil
+	case spec.HTTP != "":
+		return httpSource{url: strings.TrimSuffix(spec.HTTP, "/")}, nil
+	default:
+		return nil, fmt.Errorf("invalid source in %s: set one of dir, git, or http", defaultsPath())
+	}
+}
+
+// dirSource reads <dir>/<name>/config.yaml or <dir>/<name>.yaml.
+type dirSource struct {
+	dir string
+}
+
+func (s dirSource) String() string { return s.dir }
+
+func (s dirSource) Lookup(name string) (*Config, error) {
+	dir := filepath.Join(s.dir, name)
+	data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if os.IsNotExist(err) {
+		dir = ""
+		data, err = os.ReadFile(filepath.Join(s.dir, name+".yaml"))
+	}
+	if os.IsNotExist(err) {
+		return nil, errPersonaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if dir != "" {
+		if err := loadPersonaFiles(dir, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// gitSource clones a repository of personas into the user's own cache dir
+// (never the shared one, where other users could edit the configs) and
+// reads it like a dirSource.
+type gitSource struct {
+	url  string
+	path string
+}
+
+func (s gitSource) String() string { return s.url }
+
+func (s gitSource) Lookup(name string) (*Config, error) {
+	sum := sha256.Sum256([]byte(s.url))
+	clone := filepath.Join(userCacheDir(), "sources", hex.EncodeToString(sum[:8]))
+
+	if err := s.sync(clone); err != nil {
+		if _, statErr := os.Stat(clone); statErr != nil {
+			return nil, err
+		}
+		// Fall back to the cached clone when offline
+		fmt.Fprintf(os.Stderr, "Warning: could not update %s: %v\n", s.url, err)
+	}
+	return dirSource{dir: filepath.Join(clone, s.path)}.Lookup(name)
+}
+
+// sync clones or pulls the source, at most once per gitRefreshInterval. The
+// stamp file records every attempt, so an unreachable remote isn't retried
+// on each launch.
+func (s gitSource) sync(clone string) error {
+	stamp := clone + ".synced"
+	if _, err := os.Stat(clone); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(clone), 0755); err != nil {
+			return err
+		}
+		if err := runGit("clone", "--quiet", "--depth", "1", s.url, clone); err != nil {
+			return err
+		}
+		return os.WriteFile(stamp, nil, 0644)
+	}
+
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < gitRefreshInterval {
+		return nil
+	}
+	if err := os.WriteFile(stamp, nil, 0644); err != nil {
+		return err
+	}
+	return runGit("-C", clone, "pull", "--quiet", "--ff-only")
+}
+
+func runGit(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// httpSource fetches single-file personas from <url>/<name>.yaml.
+type httpSource struct {
+	url string
+}
+
+func (s httpSource) String() string { return s.url }
+
+func (s httpSource) Lookup(name string) (*Config, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(s.url + "/" + name + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errPersonaNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, rest)
+	}
+	return path
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir from a path -> content map.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolverOrder(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	first, second := t.TempDir(), t.TempDir()
+	writeFiles(t, first, map[string]string{
+		"both.yaml": "name: first\n",
+	})
+	writeFiles(t, second, map[string]string{
+		"both.yaml":   "name: second\n",
+		"second.yaml": "name: only-second\n",
+	})
+	r := &resolver{sources: []source{dirSource{dir: first}, dirSource{dir: second}}}
+
+	tests := []struct {
+		persona string
+		want    string
+		wantErr string
+	}{
+		{persona: "both", want: "first"},
+		{persona: "second", want: "only-second"},
+		{persona: "missing", wantErr: "config not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.persona, func(t *testing.T) {
+			cfg, err := r.Resolve(tt.persona)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %q", tt.persona, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.persona, err)
+			}
+			if cfg.Name != tt.want {
+				t.Errorf("Resolve(%q).Name = %q, want %q", tt.persona, cfg.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirSourceLayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantName   string
+		wantPrompt string
+	}{
+		{
+			name:       "single file",
+			files:      map[string]string{"p.yaml": "name: file\nprompt: from yaml\n"},
+			wantName:   "file",
+			wantPrompt: "from yaml",
+		},
+		{
+			name: "directory wins over single file",
+			files: map[string]string{
+				"p.yaml":             "name: file\n",
+				"p/config.yaml":      "name: dir\n",
+				"p/prompt.md":        "from prompt.md",
+				"p/agents/helper.md": "---\ndescription: helps\n---\nhelp out\n",
+			},
+			wantName:   "dir",
+			wantPrompt: "from prompt.md",
+		},
+		{
+			name: "config.yaml prompt overrides prompt.md",
+			files: map[string]string{
+				"p/config.yaml": "name: dir\nprompt: inline\n",
+				"p/prompt.md":   "from prompt.md",
+			},
+			wantName:   "dir",
+			wantPrompt: "inline",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			cfg, err := dirSource{dir: dir}.Lookup("p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Name != tt.wantName || cfg.Prompt != tt.wantPrompt {
+				t.Errorf("got name %q prompt %q, want %q %q", cfg.Name, cfg.Prompt, tt.wantName, tt.wantPrompt)
+			}
+			if _, ok := tt.files["p/agents/helper.md"]; ok && cfg.Agents["helper"].Prompt != "help out" {
+				t.Errorf("agent helper = %+v, want prompt %q", cfg.Agents["helper"], "help out")
+			}
+		})
+	}
+
+	if _, err := (dirSource{dir: t.TempDir()}).Lookup("p"); !errors.Is(err, errPersonaNotFound) {
+		t.Errorf("missing persona error = %v, want errPersonaNotFound", err)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/personas/found.yaml":
+			w.Write([]byte("name: found\nprompt: hi\n"))
+		case "/personas/broken.yaml":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	src := httpSource{url: srv.URL + "/personas"}
+
+	tests := []struct {
+		persona  string
+		want     string
+		notFound bool
+		wantErr  bool
+	}{
+		{persona: "found", want: "found"},
+		{persona: "missing", notFound: true},
+		{persona: "broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.persona, func(t *testing.T) {
+			cfg, err := src.Lookup(tt.persona)
+			switch {
+			case tt.notFound:
+				if !errors.Is(err, errPersonaNotFound) {
+					t.Fatalf("error = %v, want errPersonaNotFound", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, errPersonaNotFound) {
+					t.Fatalf("error = %v, want a non-not-found error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.Name != tt.want {
+					t.Errorf("Name = %q, want %q", cfg.Name, tt.want)
+				}
+			}
+		})
+	}
+
+	// A 404 falls through to the next source
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fallback := t.TempDir()
+	writeFiles(t, fallback, map[string]string{"missing.yaml": "name: fallback\n"})
+	r := &resolver{sources: []source{src, dirSource{dir: fallback}}}
+	cfg, err := r.Resolve("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "fallback" {
+		t.Errorf("Name = %q, want %q", cfg.Name, "fallback")
+	}
+}
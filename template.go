@@ -0,0 +1,220 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/init
+var initTemplates embed.FS
+
+// promptData is exposed to prompt templates, e.g. {{.WorkDir}}.
+type promptData struct {
+	Persona string
+	WorkDir string
+	Vars    map[string]string
+}
+
+// newTemplate returns the template engine shared by prompts and init files.
+func newTemplate(name string) *template.Template {
+	return template.New(name).Option("missingkey=error").Funcs(templateFuncs(""))
+}
+
+// templateFuncs are the helpers available to prompts. Data file paths are
+// relative to workDir and must stay inside it, symlinks included. Maps and
+// lists print as JSON either way; toJSON also quotes scalars.
+//
+//	{{ yaml "config/app.yaml" | toJSON }}
+//	{{ json "package.json" "scripts" }}
+func templateFuncs(workDir string) template.FuncMap {
+	load := func(kind string, unmarshal func([]byte, any) error) func(string, ...string) (any, error) {
+		return func(path string, keys ...string) (any, error) {
+			defer timings.begin(kind + " " + path)()
+			if !filepath.IsLocal(path) {
+				return nil, fmt.Errorf("%s: %s is outside the working directory", kind, path)
+			}
+			root, err := os.OpenRoot(workDir)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kind, err)
+			}
+			defer root.Close()
+			data, err := root.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kind, err)
+			}
+			var v any
+			if err := unmarshal(data, &v); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", kind, path, err)
+			}
+			return lookupKeys(normalizeData(v), keys)
+		}
+	}
+	return template.FuncMap{
+		"yaml":   load("yaml", yaml.Unmarshal),
+		"json":   load("json", json.Unmarshal),
+		"toJSON": toJSON,
+	}
+}
+
+func toJSON(v any) (string, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	return string(out), err
+}
+
+// dataMap and dataList hold parsed data files. They print as JSON rather
+// than Go's map[...] syntax, so they can go straight into a prompt.
+type dataMap map[string]any
+type dataList []any
+
+func (m dataMap) String() string {
+	out, err := toJSON(m)
+	if err != nil {
+		return fmt.Sprint(map[string]any(m))
+	}
+	return out
+}
+
+func (l dataList) String() string {
+	out, err := toJSON(l)
+	if err != nil {
+		return fmt.Sprint([]any(l))
+	}
+	return out
+}
+
+// normalizeData converts parsed data to dataMap and dataList, turning map
+// keys into strings (yaml allows e.g. numbers, which JSON doesn't).
+func normalizeData(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		m := make(dataMap, len(node))
+		for k, child := range node {
+			m[k] = normalizeData(child)
+		}
+		return m
+	case map[any]any:
+		m := make(dataMap, len(node))
+		for k, child := range node {
+			m[fmt.Sprint(k)] = normalizeData(child)
+		}
+		return m
+	case []any:
+		l := make(dataList, len(node))
+		for i, child := range node {
+			l[i] = normalizeData(child)
+		}
+		return l
+	}
+	return v
+}
+
+// lookupKeys descends into parsed data by map key or list index.
+func lookupKeys(v any, keys []string) (any, error) {
+	for _, key := range keys {
+		switch node := v.(type) {
+		case dataMap:
+			next, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("key not found: %s", key)
+			}
+			v = next
+		case dataList:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("invalid index: %s", key)
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("cannot look up %s in a scalar", key)
+		}
+	}
+	return v, nil
+}
+
+func execTemplate(t *template.Template, data any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// expandWorkDir is the plain substitution applied to prompts that aren't Go
+// templates: $WorkDir and a literal {{.WorkDir}}, and nothing else, so other
+// {{ }} text (e.g. Handlebars examples) passes through untouched.
+func expandWorkDir(prompt, workDir string) string {
+	prompt = os.Expand(prompt, func(key string) string {
+		switch key {
+		case "WorkDir":
+			return workDir
+		default:
+			return "$" + key // preserve unknown variables
+		}
+	})
+	return strings.ReplaceAll(prompt, "{{.WorkDir}}", workDir)
+}
+
+// renderPrompt expands $WorkDir style variables, then renders the prompt as
+// a Go template. A prompt that doesn't parse as one is used as is, unless
+// strict (see requiresTemplate).
+func renderPrompt(prompt string, data promptData, strict bool) (string, error) {
+	prompt = expandWorkDir(prompt, data.WorkDir)
+
+	t, err := newTemplate(data.Persona).Parse(prompt)
+	if err != nil && !strict {
+		return prompt, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	t.Funcs(templateFuncs(data.WorkDir))
+	out, err := execTemplate(t, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	return out, nil
+}
+
+// renderInit renders the embedded init templates into dir. Init templates
+// use [[ ]] delimiters so {{ }} passes through to the generated prompt.
+func renderInit(dir, persona string) ([]string, error) {
+	var created []string
+	err := fs.WalkDir(initTemplates, "templates/init", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel("templates/init", path)
+		src, err := initTemplates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		t, err := newTemplate(rel).Delims("[[", "]]").Parse(string(src))
+		if err != nil {
+			return err
+		}
+		out, err := execTemplate(t, promptData{Persona: persona})
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, []byte(out), 0644); err != nil {
+			return err
+		}
+		created = append(created, dest)
+		return nil
+	})
+	return created, err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// timedStep is one instrumented part of startup. Self excludes the time
+// spent in nested steps (e.g. data files read while rendering the prompt).
+type timedStep struct {
+	name     string
+	depth    int
+	duration time.Duration
+	self     time.Duration
+}
+
+// launchTimer records how long config loading, rendering, and other
+// startup steps take, so slow launches can be traced to their cause.
+type launchTimer struct {
+	start time.Time
+	steps []timedStep
+	open  []int
+}
+
+var timings = &launchTimer{start: time.Now()}
+
+// begin starts a step; call the returned func when it finishes.
+func (t *launchTimer) begin(name string) func() {
+	i := len(t.steps)
+	t.steps = append(t.steps, timedStep{name: name, depth: len(t.open)})
+	t.open = append(t.open, i)
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		t.open = t.open[:len(t.open)-1]
+		t.steps[i].duration = d
+		t.steps[i].self += d
+		if n := len(t.open); n > 0 {
+			t.steps[t.open[n-1]].self -= d
+		}
+	}
+}
+
+// report prints the steps when verbose and warns when startup exceeded the
+// threshold (0 disables the warning).
+func (t *launchTimer) report(verbose bool, thresholdMS int) {
+	total := time.Since(t.start)
+	if verbose {
+		for _, step := range t.steps {
+			name := strings.Repeat("  ", step.depth) + step.name
+			fmt.Fprintf(os.Stderr, "unum: %-40s %8.1fms\n", name, ms(step.duration))
+		}
+		fmt.Fprintf(os.Stderr, "unum: %-40s %8.1fms\n", "total", ms(total))
+	}
+
+	if thresholdMS <= 0 || total < time.Duration(thresholdMS)*time.Millisecond || len(t.steps) == 0 {
+		return
+	}
+	slowest := t.steps[0]
+	for _, step := range t.steps[1:] {
+		if step.self > slowest.self {
+			slowest = step
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: unum took %.0fms to start; slowest step: %s (%.0fms)\n", ms(total), slowest.name, ms(slowest.self))
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// validate checks a persona config without launching it.
+func validate(persona string) error {
+	_, cfg, err := resolvePersona(persona)
+	if err != nil {
+		return err
+	}
+
+	conflicts, errs, warnings := claudeBackend.validateArgs(cfg.Args)
+	errs = append(conflicts, errs...)
+
+	if _, err := newTemplate(persona).Parse(cfg.Prompt); err != nil {
+		if requiresTemplate(cfg) {
+			errs = append(errs, fmt.Sprintf("invalid prompt template: %v", err))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("prompt is not a valid template, so {{ }} is passed through as is: %v", err))
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Vars))
+	for name := range cfg.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := cfg.Vars[name]
+		if spec.Default != "" && len(spec.Enum) > 0 && !slices.Contains(spec.Enum, spec.Default) {
+			errs = append(errs, fmt.Sprintf("var %q: default %q is not one of its enum values", name, spec.Default))
+		}
+	}
+
+	if cfg.Isolation != "" {
+		if _, err := loadIsolation(cfg.Isolation); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for _, w := range warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+	for _, e := range errs {
+		fmt.Printf("error: %s\n", e)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s has %d error(s)", persona, len(errs))
+	}
+	fmt.Printf("%s: ok\n", persona)
+	return nil
+}
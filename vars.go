@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// VarSpec declares a prompt variable, referenced as {{.Vars.name}}.
+type VarSpec struct {
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Enum        []string `yaml:"enum"`
+	Default     string   `yaml:"default"`
+}
+
+// splitVars pulls --var key=value flags out of the args meant for claude.
+func splitVars(args []string) (map[string]string, []string, error) {
+	vars := make(map[string]string)
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		var kv string
+		switch {
+		case args[i] == "--var":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--var requires key=value")
+			}
+			i++
+			kv = args[i]
+		case strings.HasPrefix(args[i], "--var="):
+			kv = strings.TrimPrefix(args[i], "--var=")
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, nil, fmt.Errorf("invalid --var %q (expected key=value)", kv)
+		}
+		vars[key] = value
+	}
+	return vars, rest, nil
+}
+
+// resolveVars checks the given values against the config's declarations and
+// fills in defaults.
+func resolveVars(specs map[string]VarSpec, given map[string]string) (map[string]string, error) {
+	for key := range given {
+		if _, ok := specs[key]; !ok {
+			return nil, fmt.Errorf("unknown var %q (declare it under vars: in the persona config)", key)
+		}
+	}
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make(map[string]string, len(specs))
+	for _, name := range names {
+		spec := specs[name]
+		value, ok := given[name]
+		if !ok {
+			if spec.Required {
+				hint := "..."
+				if len(spec.Enum) > 0 {
+					hint = strings.Join(spec.Enum, "|")
+				}
+				return nil, fmt.Errorf("missing required var %q (pass --var %s=%s)", name, name, hint)
+			}
+			value = spec.Default
+		}
+		if ok && len(spec.Enum) > 0 && !slices.Contains(spec.Enum, value) {
+			return nil, fmt.Errorf("invalid value %q for var %q (expected one of: %s)", value, name, strings.Join(spec.Enum, ", "))
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}